@@ -8,8 +8,11 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/betalo-sweden/moq/pkg/moq"
+	"github.com/betalo-sweden/moq/pkg/moq/config"
 )
 
 func main() {
@@ -17,8 +20,12 @@ func main() {
 	log.SetFlags(0)
 
 	var (
-		outFile = flag.String("out", "", "output file (default stdout)")
-		pkgName = flag.String("pkg", "", "package name (default will infer)")
+		outFile    = flag.String("out", "", "output file (default stdout)")
+		pkgName    = flag.String("pkg", "", "package name (default will infer)")
+		tags       = flag.String("tags", "", "comma-separated list of build tags to apply")
+		style      = flag.String("style", "", "mock style to generate: \"\" for the default FooFunc callback style, \"expect\" to also emit a gomock-style mock.EXPECT() matcher API")
+		reflectPkg = flag.String("reflect", "", "import path of a third-party package to mock by reflection, instead of parsing local source")
+		configFile = flag.String("config", "", "path to a .moq.yaml batch-generation config; when set, all other flags and positional args are ignored")
 	)
 	flag.Usage = func() {
 		fmt.Println(`moq [flags] destination interface [interface2 [interface3 [...]]]`)
@@ -26,39 +33,98 @@ func main() {
 	}
 	flag.Parse()
 	args := flag.Args()
+
+	if len(*configFile) > 0 {
+		if err := config.Run(*configFile); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	if len(*reflectPkg) > 0 {
+		if len(args) < 1 {
+			fmt.Fprintln(os.Stderr, "not enough arguments")
+			flag.Usage()
+			os.Exit(1)
+		}
+		if err := generateReflect(*reflectPkg, *pkgName, moq.Style(*style), *outFile, args); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
 	if len(args) < 2 {
 		fmt.Fprintln(os.Stderr, "not enough arguments")
 		flag.Usage()
 		os.Exit(1)
 	}
 
-	destination := args[0]
-	args = args[1:]
+	pattern := args[0]
+	ifaces := args[1:]
 
-	// setup mock context
-	m, err := moq.New(destination, *pkgName)
+	dirs, err := moq.Dirs(pattern, *tags)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
+	for _, dir := range dirs {
+		if err := generate(dir, *pkgName, *tags, *outFile, moq.Style(*style), len(dirs) > 1, ifaces); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+// generateReflect handles the -reflect path: it mocks interfaces found in a
+// compiled, third-party package rather than parsing local source.
+func generateReflect(importPath, pkgName string, style moq.Style, outFile string, ifaces []string) error {
+	m, err := moq.NewReflect(importPath, pkgName, style)
+	if err != nil {
+		return err
+	}
+
 	var buf bytes.Buffer
-	var out io.Writer
-	out = os.Stdout
-	if len(*outFile) > 0 {
+	var out io.Writer = os.Stdout
+	if len(outFile) > 0 {
 		out = &buf
 	}
 
-	// generate mock source
-	err = m.Mock(out, args...)
+	if err := m.MockReflect(out, ifaces...); err != nil {
+		return err
+	}
+
+	if len(outFile) > 0 {
+		return ioutil.WriteFile(outFile, buf.Bytes(), 0644)
+	}
+	return nil
+}
+
+// generate runs the mocker for a single resolved package directory. When
+// multi is set (the pattern matched more than one package), outFile is
+// ignored in favor of a per-package filename so each package gets its own
+// *_mock.go.
+func generate(dir, pkgName, tags, outFile string, style moq.Style, multi bool, ifaces []string) error {
+	m, err := moq.New(dir, pkgName, tags, style)
 	if err != nil {
-		log.Fatalln(err)
+		return err
 	}
 
-	// create the file
-	if len(*outFile) > 0 {
-		err = ioutil.WriteFile(*outFile, buf.Bytes(), 0644)
-		if err != nil {
-			log.Fatalln(err)
-		}
+	dest := outFile
+	if multi {
+		dest = filepath.Join(dir, strings.ToLower(m.PackageName())+"_mock.go")
+	}
+
+	var buf bytes.Buffer
+	var out io.Writer = os.Stdout
+	if len(dest) > 0 {
+		out = &buf
+	}
+
+	if err := m.Mock(out, ifaces...); err != nil {
+		return err
+	}
+
+	if len(dest) > 0 {
+		return ioutil.WriteFile(dest, buf.Bytes(), 0644)
 	}
+	return nil
 }
@@ -0,0 +1,182 @@
+// Package config implements moq's -config batch mode: generating mocks for
+// many packages and interfaces in one run from a YAML spec, instead of one
+// `moq` invocation per interface.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/betalo-sweden/moq/pkg/moq"
+)
+
+// Config is the top-level shape of a .moq.yaml file:
+//
+//	packages:
+//	  github.com/me/project/foo:
+//	    interfaces:
+//	      PersonStore:
+//	        config: { filename: "person_store_mock.go", mockname: "{{.InterfaceName}}Mock" }
+//	      Logger: {}
+//	  github.com/me/project/bar:
+//	    config: { dir: "./bar/mocks", pkgname: "barmocks" }
+//	    interfaces: { Cache: {} }
+type Config struct {
+	Packages map[string]Package `yaml:"packages"`
+}
+
+// Package configures generation for one package, keyed in Config.Packages
+// by a directory, import path, or pattern accepted by moq.Dirs. The key must
+// resolve to exactly one package; a pattern matching several is an error.
+type Package struct {
+	Config     Output               `yaml:"config"`
+	Interfaces map[string]Interface `yaml:"interfaces"`
+}
+
+// Interface configures generation for a single interface, overriding its
+// package's defaults.
+type Interface struct {
+	Config Output `yaml:"config"`
+}
+
+// Output controls where and how mocks are written. Any zero-valued field
+// falls back to moq's usual default for that setting.
+type Output struct {
+	// Dir is the output directory, relative to the package directory
+	// unless it's absolute. Only meaningful at package scope.
+	Dir string `yaml:"dir"`
+	// Filename is the output file name. Only meaningful at interface
+	// scope; defaults to the lowercased package name plus "_mock.go".
+	Filename string `yaml:"filename"`
+	// PkgName overrides the generated file's package name. Only
+	// meaningful at package scope.
+	PkgName string `yaml:"pkgname"`
+	// MockName is a text/template, evaluated against
+	// {InterfaceName, PackageName}, naming the generated mock type.
+	// Defaults to "{{.InterfaceName}}Mock".
+	MockName string `yaml:"mockname"`
+	// Tags is a comma-separated list of build tags to apply when loading
+	// the package. Only meaningful at package scope.
+	Tags string `yaml:"tags"`
+}
+
+// Load reads and parses the .moq.yaml file at path.
+func Load(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %s", path, err)
+	}
+	return &cfg, nil
+}
+
+// Run generates every mock described by the config file at path.
+func Run(path string) error {
+	cfg, err := Load(path)
+	if err != nil {
+		return err
+	}
+
+	for pattern, pkg := range cfg.Packages {
+		if err := runPackage(pattern, pkg); err != nil {
+			return fmt.Errorf("%s: %s", pattern, err)
+		}
+	}
+	return nil
+}
+
+// runPackage resolves one packages entry and generates its interfaces,
+// grouping interfaces that share a resolved output filename into a single
+// generated file.
+func runPackage(pattern string, pkg Package) error {
+	dirs, err := moq.Dirs(pattern, pkg.Config.Tags)
+	if err != nil {
+		return err
+	}
+	if len(dirs) != 1 {
+		return fmt.Errorf("expected exactly one package, found %d", len(dirs))
+	}
+	dir := dirs[0]
+
+	outDir := dir
+	if len(pkg.Config.Dir) > 0 {
+		outDir = pkg.Config.Dir
+		if !filepath.IsAbs(outDir) {
+			outDir = filepath.Join(dir, outDir)
+		}
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			return err
+		}
+	}
+
+	m, err := moq.New(dir, pkg.Config.PkgName, pkg.Config.Tags, moq.StyleCallback)
+	if err != nil {
+		return err
+	}
+
+	byFilename := make(map[string][]string)
+	for name, iface := range pkg.Interfaces {
+		mockName, err := renderMockName(firstNonEmpty(iface.Config.MockName, pkg.Config.MockName), name, m.PackageName())
+		if err != nil {
+			return fmt.Errorf("interface %s: %s", name, err)
+		}
+		if len(mockName) > 0 {
+			m.SetMockName(name, mockName)
+		}
+
+		filename := firstNonEmpty(iface.Config.Filename, strings.ToLower(m.PackageName())+"_mock.go")
+		byFilename[filename] = append(byFilename[filename], name)
+	}
+
+	for filename, names := range byFilename {
+		var buf bytes.Buffer
+		if err := m.Mock(&buf, names...); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(outDir, filename), buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderMockName evaluates tmplText, a text/template such as
+// "{{.InterfaceName}}Mock", against the given interface and package name.
+// An empty tmplText renders to "", leaving Mocker's own default in place.
+func renderMockName(tmplText, ifaceName, pkgName string) (string, error) {
+	if len(tmplText) == 0 {
+		return "", nil
+	}
+	tmpl, err := template.New("mockname").Parse(tmplText)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, struct {
+		InterfaceName string
+		PackageName   string
+	}{ifaceName, pkgName})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if len(s) > 0 {
+			return s
+		}
+	}
+	return ""
+}
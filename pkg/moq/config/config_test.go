@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".moq.yaml")
+	const doc = `
+packages:
+  github.com/me/project/foo:
+    interfaces:
+      PersonStore:
+        config: { filename: "person_store_mock.go", mockname: "{{.InterfaceName}}Mock" }
+      Logger: {}
+  github.com/me/project/bar:
+    config: { dir: "./bar/mocks", pkgname: "barmocks" }
+    interfaces: { Cache: {} }
+`
+	if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+
+	foo, ok := cfg.Packages["github.com/me/project/foo"]
+	if !ok {
+		t.Fatal("expected a \"github.com/me/project/foo\" package entry")
+	}
+	personStore, ok := foo.Interfaces["PersonStore"]
+	if !ok {
+		t.Fatal("expected a PersonStore interface entry")
+	}
+	if personStore.Config.Filename != "person_store_mock.go" {
+		t.Errorf("PersonStore.Config.Filename = %q, want %q", personStore.Config.Filename, "person_store_mock.go")
+	}
+	if personStore.Config.MockName != "{{.InterfaceName}}Mock" {
+		t.Errorf("PersonStore.Config.MockName = %q, want %q", personStore.Config.MockName, "{{.InterfaceName}}Mock")
+	}
+	if _, ok := foo.Interfaces["Logger"]; !ok {
+		t.Error("expected a Logger interface entry")
+	}
+
+	bar, ok := cfg.Packages["github.com/me/project/bar"]
+	if !ok {
+		t.Fatal("expected a \"github.com/me/project/bar\" package entry")
+	}
+	if bar.Config.Dir != "./bar/mocks" {
+		t.Errorf("bar.Config.Dir = %q, want %q", bar.Config.Dir, "./bar/mocks")
+	}
+	if bar.Config.PkgName != "barmocks" {
+		t.Errorf("bar.Config.PkgName = %q, want %q", bar.Config.PkgName, "barmocks")
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error loading a nonexistent file")
+	}
+}
+
+func TestRenderMockName(t *testing.T) {
+	cases := []struct {
+		name      string
+		tmplText  string
+		ifaceName string
+		pkgName   string
+		want      string
+		wantErr   bool
+	}{
+		{name: "empty template leaves the default in place", tmplText: "", ifaceName: "Store", pkgName: "foo", want: ""},
+		{name: "interface name", tmplText: "{{.InterfaceName}}Mock", ifaceName: "Store", pkgName: "foo", want: "StoreMock"},
+		{name: "package name", tmplText: "{{.PackageName}}{{.InterfaceName}}", ifaceName: "Store", pkgName: "foo", want: "fooStore"},
+		{name: "invalid template", tmplText: "{{.Nonexistent", ifaceName: "Store", pkgName: "foo", wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := renderMockName(c.tmplText, c.ifaceName, c.pkgName)
+			if c.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderMockName: %s", err)
+			}
+			if got != c.want {
+				t.Errorf("renderMockName(%q, %q, %q) = %q, want %q", c.tmplText, c.ifaceName, c.pkgName, got, c.want)
+			}
+		})
+	}
+}
+
+func TestFirstNonEmpty(t *testing.T) {
+	cases := []struct {
+		name string
+		ss   []string
+		want string
+	}{
+		{name: "interface override wins over package default", ss: []string{"iface_mock.go", "pkg_mock.go"}, want: "iface_mock.go"},
+		{name: "falls back to package default", ss: []string{"", "pkg_mock.go"}, want: "pkg_mock.go"},
+		{name: "falls back past multiple empties", ss: []string{"", "", "default"}, want: "default"},
+		{name: "all empty", ss: []string{"", ""}, want: ""},
+		{name: "no args", ss: nil, want: ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := firstNonEmpty(c.ss...); got != c.want {
+				t.Errorf("firstNonEmpty(%v) = %q, want %q", c.ss, got, c.want)
+			}
+		})
+	}
+}
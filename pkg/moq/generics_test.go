@@ -0,0 +1,39 @@
+package moq_test
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/betalo-sweden/moq/pkg/moq"
+)
+
+// TestGenerateGenerics generates a mock for the generic Store interface in
+// testpackages/generics and checks that the result compiles and vets
+// cleanly, guarding against regressions in moq's type-parameter support.
+func TestGenerateGenerics(t *testing.T) {
+	const srcDir = "testpackages/generics"
+
+	m, err := moq.New(srcDir, "", "", moq.StyleCallback)
+	if err != nil {
+		t.Fatalf("moq.New: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := m.Mock(&buf, "Store"); err != nil {
+		t.Fatalf("Mock: %s", err)
+	}
+
+	outFile := filepath.Join(srcDir, "store_mock.go")
+	if err := os.WriteFile(outFile, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("writing %s: %s", outFile, err)
+	}
+	defer os.Remove(outFile)
+
+	out, err := exec.Command("go", "vet", "./"+srcDir+"/...").CombinedOutput()
+	if err != nil {
+		t.Fatalf("go vet generated mock: %s\n%s", err, out)
+	}
+}
@@ -0,0 +1,136 @@
+// Package matcher provides argument matchers for moq's -style=expect mocks,
+// modeled after gomock's matcher API.
+package matcher
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Matcher matches a method argument against some expectation.
+type Matcher interface {
+	// Matches reports whether x satisfies the matcher.
+	Matches(x interface{}) bool
+	// String describes the matcher for use in failure messages.
+	String() string
+}
+
+// Eq returns a Matcher that matches x against a value equal to v, using
+// reflect.DeepEqual.
+func Eq(v interface{}) Matcher {
+	return eqMatcher{v}
+}
+
+type eqMatcher struct {
+	v interface{}
+}
+
+func (m eqMatcher) Matches(x interface{}) bool {
+	return reflect.DeepEqual(m.v, x)
+}
+
+func (m eqMatcher) String() string {
+	return fmt.Sprintf("is equal to %v", m.v)
+}
+
+// Any returns a Matcher that matches any value, including nil.
+func Any() Matcher {
+	return anyMatcher{}
+}
+
+type anyMatcher struct{}
+
+func (anyMatcher) Matches(interface{}) bool { return true }
+func (anyMatcher) String() string           { return "is anything" }
+
+// Nil returns a Matcher that matches if the argument is nil.
+func Nil() Matcher {
+	return nilMatcher{}
+}
+
+type nilMatcher struct{}
+
+func (nilMatcher) Matches(x interface{}) bool {
+	if x == nil {
+		return true
+	}
+	v := reflect.ValueOf(x)
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+func (nilMatcher) String() string { return "is nil" }
+
+// Not returns a Matcher that matches when m does not.
+func Not(m Matcher) Matcher {
+	return notMatcher{m}
+}
+
+type notMatcher struct {
+	m Matcher
+}
+
+func (n notMatcher) Matches(x interface{}) bool {
+	return !n.m.Matches(x)
+}
+
+func (n notMatcher) String() string {
+	return "not(" + n.m.String() + ")"
+}
+
+// AssignableToTypeOf returns a Matcher that matches if the argument's type
+// is assignable to the type of v.
+func AssignableToTypeOf(v interface{}) Matcher {
+	return assignableToTypeOfMatcher{reflect.TypeOf(v)}
+}
+
+type assignableToTypeOfMatcher struct {
+	t reflect.Type
+}
+
+func (m assignableToTypeOfMatcher) Matches(x interface{}) bool {
+	if x == nil {
+		return m.t == nil
+	}
+	return reflect.TypeOf(x).AssignableTo(m.t)
+}
+
+func (m assignableToTypeOfMatcher) String() string {
+	return fmt.Sprintf("is assignable to %v", m.t)
+}
+
+// Len returns a Matcher that matches if the argument has length n, for
+// arguments of kinds Array, Chan, Map, Slice, and String.
+func Len(n int) Matcher {
+	return lenMatcher{n}
+}
+
+type lenMatcher struct {
+	n int
+}
+
+func (m lenMatcher) Matches(x interface{}) bool {
+	v := reflect.ValueOf(x)
+	switch v.Kind() {
+	case reflect.Array, reflect.Chan, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == m.n
+	default:
+		return false
+	}
+}
+
+func (m lenMatcher) String() string {
+	return fmt.Sprintf("has length %d", m.n)
+}
+
+// Wrap wraps a plain value into a Matcher, using Eq, unless it already is one.
+func Wrap(v interface{}) Matcher {
+	if m, ok := v.(Matcher); ok {
+		return m
+	}
+	return Eq(v)
+}
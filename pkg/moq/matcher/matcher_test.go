@@ -0,0 +1,94 @@
+package matcher_test
+
+import (
+	"testing"
+
+	"github.com/betalo-sweden/moq/pkg/moq/matcher"
+)
+
+func TestEq(t *testing.T) {
+	m := matcher.Eq(42)
+	if !m.Matches(42) {
+		t.Error("expected Eq(42) to match 42")
+	}
+	if m.Matches(43) {
+		t.Error("expected Eq(42) not to match 43")
+	}
+}
+
+func TestAny(t *testing.T) {
+	m := matcher.Any()
+	for _, x := range []interface{}{42, "s", nil, struct{}{}} {
+		if !m.Matches(x) {
+			t.Errorf("expected Any() to match %v", x)
+		}
+	}
+}
+
+func TestNil(t *testing.T) {
+	m := matcher.Nil()
+	var p *int
+	cases := []struct {
+		x     interface{}
+		wants bool
+	}{
+		{nil, true},
+		{p, true},
+		{0, false},
+		{"", false},
+	}
+	for _, c := range cases {
+		if got := m.Matches(c.x); got != c.wants {
+			t.Errorf("Nil().Matches(%#v) = %v, want %v", c.x, got, c.wants)
+		}
+	}
+}
+
+func TestNot(t *testing.T) {
+	m := matcher.Not(matcher.Eq(42))
+	if m.Matches(42) {
+		t.Error("expected Not(Eq(42)) not to match 42")
+	}
+	if !m.Matches(43) {
+		t.Error("expected Not(Eq(42)) to match 43")
+	}
+}
+
+func TestAssignableToTypeOf(t *testing.T) {
+	m := matcher.AssignableToTypeOf(0)
+	if !m.Matches(42) {
+		t.Error("expected AssignableToTypeOf(0) to match an int")
+	}
+	if m.Matches("s") {
+		t.Error("expected AssignableToTypeOf(0) not to match a string")
+	}
+}
+
+func TestLen(t *testing.T) {
+	m := matcher.Len(3)
+	cases := []struct {
+		x     interface{}
+		wants bool
+	}{
+		{"abc", true},
+		{"ab", false},
+		{[]int{1, 2, 3}, true},
+		{[]int{1, 2}, false},
+		{42, false},
+	}
+	for _, c := range cases {
+		if got := m.Matches(c.x); got != c.wants {
+			t.Errorf("Len(3).Matches(%#v) = %v, want %v", c.x, got, c.wants)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if matcher.Wrap(matcher.Any()) != matcher.Any() {
+		t.Error("expected Wrap to pass an existing Matcher through unchanged")
+	}
+	wrapped := matcher.Wrap(42)
+	if !wrapped.Matches(42) {
+		t.Error("expected Wrap(42) to behave like Eq(42)")
+	}
+}
@@ -0,0 +1,173 @@
+// Package model is a JSON-serializable description of a Go interface and
+// the types its methods touch. It lets moq's -reflect mode hand off a
+// description of an interface living in a compiled, third-party package
+// from a throwaway driver program back to the main moq process.
+package model
+
+import (
+	"fmt"
+	"path"
+)
+
+// Package is the reflected description of one or more interfaces found in a
+// compiled package.
+type Package struct {
+	Name       string       `json:"name"`
+	Interfaces []*Interface `json:"interfaces"`
+}
+
+// Interface describes a single reflected interface.
+type Interface struct {
+	Name    string    `json:"name"`
+	Methods []*Method `json:"methods"`
+}
+
+// Method describes a single method of a reflected interface.
+type Method struct {
+	Name     string       `json:"name"`
+	In       []*Parameter `json:"in,omitempty"`
+	Out      []*Parameter `json:"out,omitempty"`
+	Variadic bool         `json:"variadic"`
+}
+
+// Parameter describes one method parameter or return value.
+type Parameter struct {
+	Name string `json:"name,omitempty"`
+	Type *Type  `json:"type"`
+}
+
+// Kind discriminates the shape a reflected Type was built from.
+type Kind int
+
+// The kinds of Type moq's reflect mode can describe. Unlike go/types, we
+// don't need the full type-checker lattice - just enough shape to respell
+// the type as Go source.
+const (
+	Predeclared Kind = iota
+	Named
+	Pointer
+	Slice
+	Array
+	Map
+	Chan
+	Func
+	EmptyInterface
+)
+
+// Type is a JSON-serializable stand-in for a reflect.Type, capturing either
+// a predeclared identifier, a named type (with its import path so callers
+// can qualify and import it), a pointer/slice/array/map/chan wrapper around
+// another Type, or a function signature.
+type Type struct {
+	Kind Kind `json:"kind"`
+
+	// Predeclared: the identifier itself, e.g. "int", "string", "error".
+	PredeclaredName string `json:"predeclaredName,omitempty"`
+
+	// Named
+	ImportPath  string `json:"importPath,omitempty"`
+	PackageName string `json:"packageName,omitempty"`
+	TypeName    string `json:"typeName,omitempty"`
+
+	// Pointer, Slice, Array, Chan, and Map (the value type)
+	Elem *Type `json:"elem,omitempty"`
+	Len  int   `json:"len,omitempty"`
+	// ChanDir is "", "<-chan", or "chan<-"; "" means bidirectional.
+	ChanDir string `json:"chanDir,omitempty"`
+
+	// Map
+	Key *Type `json:"key,omitempty"`
+
+	// Func
+	In       []*Type `json:"in,omitempty"`
+	Out      []*Type `json:"out,omitempty"`
+	Variadic bool    `json:"variadic,omitempty"`
+}
+
+// Qualifier returns the Go source spelling for a named type given its import
+// path, declared package name, and type name, registering the import path
+// as a side effect if needed. Callers pass one backed by whatever
+// import-tracking they use.
+type Qualifier func(importPath, packageName, typeName string) string
+
+// String renders t as Go source, using qualify to spell out any named type.
+func (t *Type) String(qualify Qualifier) string {
+	switch t.Kind {
+	case Predeclared:
+		return t.PredeclaredName
+	case Named:
+		return qualify(t.ImportPath, t.PackageName, t.TypeName)
+	case Pointer:
+		return "*" + t.Elem.String(qualify)
+	case Slice:
+		return "[]" + t.Elem.String(qualify)
+	case Array:
+		return fmt.Sprintf("[%d]%s", t.Len, t.Elem.String(qualify))
+	case Map:
+		return fmt.Sprintf("map[%s]%s", t.Key.String(qualify), t.Elem.String(qualify))
+	case Chan:
+		switch t.ChanDir {
+		case "<-chan":
+			return "<-chan " + t.Elem.String(qualify)
+		case "chan<-":
+			return "chan<- " + t.Elem.String(qualify)
+		default:
+			return "chan " + t.Elem.String(qualify)
+		}
+	case Func:
+		return t.funcString(qualify)
+	case EmptyInterface:
+		return "interface{}"
+	default:
+		return "<invalid type>"
+	}
+}
+
+func (t *Type) funcString(qualify Qualifier) string {
+	in := make([]string, len(t.In))
+	for i, p := range t.In {
+		if t.Variadic && i == len(t.In)-1 {
+			in[i] = "..." + p.String(qualify)[2:]
+			continue
+		}
+		in[i] = p.String(qualify)
+	}
+	out := make([]string, len(t.Out))
+	for i, p := range t.Out {
+		out[i] = p.String(qualify)
+	}
+	s := "func("
+	for i, p := range in {
+		if i > 0 {
+			s += ", "
+		}
+		s += p
+	}
+	s += ")"
+	switch len(out) {
+	case 0:
+	case 1:
+		s += " " + out[0]
+	default:
+		s += " (" + joinComma(out) + ")"
+	}
+	return s
+}
+
+func joinComma(ss []string) string {
+	s := ""
+	for i, v := range ss {
+		if i > 0 {
+			s += ", "
+		}
+		s += v
+	}
+	return s
+}
+
+// DefaultPackageName guesses a Go package name from its import path, taking
+// the last path element, the same fallback `go build` itself uses when it
+// can't otherwise determine a package's name.
+func DefaultPackageName(importPath string) string {
+	return path.Base(importPath)
+}
@@ -4,10 +4,6 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"go/ast"
-	"go/build"
-	"go/parser"
-	"go/token"
 	"go/types"
 	"io"
 	"os"
@@ -16,10 +12,15 @@ import (
 	"strings"
 	"text/template"
 
-	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/imports"
 )
 
+// packagesLoadMode is the set of information we need go/packages to collect
+// in order to resolve an interface's method set and every type it touches.
+const packagesLoadMode = packages.NeedName | packages.NeedTypes | packages.NeedTypesInfo |
+	packages.NeedSyntax | packages.NeedImports | packages.NeedDeps | packages.NeedModule
+
 // This list comes from the golint codebase. Golint will complain about any of
 // these being mixed-case, like "Id" instead of "ID".
 var golintInitialisms = []string{
@@ -63,63 +64,162 @@ var golintInitialisms = []string{
 	"XSS",
 }
 
+// Style selects which shape of mock Mocker generates.
+type Style string
+
+const (
+	// StyleCallback is the default: each method is backed by a `FooFunc`
+	// field the caller assigns a closure to.
+	StyleCallback Style = ""
+	// StyleExpect additionally emits a gomock-style `mock.EXPECT()`
+	// matcher/expectation surface alongside the callback fields.
+	StyleExpect Style = "expect"
+)
+
 // Mocker can generate mock structs.
 type Mocker struct {
 	src     string
 	tmpl    *template.Template
-	fset    *token.FileSet
-	pkgs    map[string]*ast.Package
+	pkg     *packages.Package
 	pkgName string
 	pkgPath string
+	style   Style
+
+	// reflectImportPath is set instead of pkg/src by NewReflect, for mocks
+	// built by reflecting on a compiled package rather than parsing source.
+	reflectImportPath string
+
+	// mockNames overrides the generated type name for an interface; absent
+	// entries default to the interface name plus "Mock". Set via
+	// SetMockName, used by the -config batch runner.
+	mockNames map[string]string
+
+	// oldMocksRemoved tracks whether Mock has already cleaned out m.src's
+	// existing *_mock.go files. A batch caller may call Mock more than
+	// once against the same Mocker to split interfaces across several
+	// output files; without this, the second call's cleanup would delete
+	// the file the first call just wrote.
+	oldMocksRemoved bool
 
 	imports map[string]bool
 }
 
-// New makes a new Mocker for the specified package directory.
-func New(src, packageName string) (*Mocker, error) {
-	fset := token.NewFileSet()
-	noTestFiles := func(i os.FileInfo) bool {
-		return !strings.HasSuffix(i.Name(), "_test.go")
+// SetMockName overrides the name of the generated mock type for iface,
+// which otherwise defaults to iface + "Mock".
+func (m *Mocker) SetMockName(iface, name string) {
+	if m.mockNames == nil {
+		m.mockNames = make(map[string]string)
 	}
+	m.mockNames[iface] = name
+}
 
-	pkgs, err := parser.ParseDir(fset, src, noTestFiles, parser.SpuriousErrors)
-	if err != nil {
-		return nil, err
+func (m *Mocker) mockName(iface string) string {
+	if name := m.mockNames[iface]; len(name) > 0 {
+		return name
 	}
-	if len(packageName) == 0 {
+	return iface + "Mock"
+}
 
-		for pkgName := range pkgs {
-			if strings.Contains(pkgName, "_test") {
-				continue
-			}
-			packageName = pkgName
-			break
-		}
+// New makes a new Mocker for the package found at src, which must already be
+// a resolved filesystem directory - it is loaded directly via
+// packages.Config{Dir: src}, so an import path or a pattern such as "./..."
+// will fail to load. Use Dirs to resolve those into directories first. tags
+// is a comma-separated list of build tags to apply while loading the
+// package, as accepted by `go build -tags`. style selects the shape of the
+// generated mock; the zero value, StyleCallback, matches moq's historical
+// output.
+//
+// When src resolves to more than one package, use Dirs to enumerate them and
+// call New once per directory.
+func New(src, packageName, tags string, style Style) (*Mocker, error) {
+	cfg := &packages.Config{
+		Mode:       packagesLoadMode,
+		Dir:        src,
+		BuildFlags: buildFlags(tags),
+	}
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil {
+		return nil, fmt.Errorf("loading package: %s", err)
 	}
-	if len(packageName) == 0 {
-		return nil, errors.New("failed to determine package name")
+	if len(pkgs) == 0 {
+		return nil, errors.New("failed to find package")
 	}
-	pkgPath, err := pkgPath(src)
-	if err != nil {
-		return nil, err
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return nil, pkg.Errors[0]
+	}
+
+	if len(packageName) == 0 {
+		packageName = pkg.Name
 	}
-	pkgPath = filepath.Join(filepath.Dir(pkgPath), packageName)
 
-	tmpl, err := template.New("moq").Funcs(templateFuncs).Parse(moqTemplate)
+	tmplText := moqTemplate
+	if style == StyleExpect {
+		tmplText = moqExpectTemplate
+	}
+	tmpl, err := template.New("moq").Funcs(templateFuncs).Parse(tmplText)
 	if err != nil {
 		return nil, err
 	}
 	return &Mocker{
 		src:     src,
 		tmpl:    tmpl,
-		fset:    fset,
-		pkgs:    pkgs,
+		pkg:     pkg,
 		pkgName: packageName,
-		pkgPath: pkgPath,
+		pkgPath: pkg.PkgPath,
+		style:   style,
 		imports: make(map[string]bool),
 	}, nil
 }
 
+// PackageName returns the name of the package being mocked.
+func (m *Mocker) PackageName() string {
+	return m.pkgName
+}
+
+// Dirs resolves pattern - a directory, an import path, or a pattern such as
+// "./..." - to the source directories of every package it matches, honoring
+// tags the same way New does. It lets callers mock an entire module tree in
+// one invocation instead of shelling out per package.
+func Dirs(pattern, tags string) ([]string, error) {
+	cfg := &packages.Config{
+		Mode:       packages.NeedName | packages.NeedFiles,
+		BuildFlags: buildFlags(tags),
+	}
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("loading packages: %s", err)
+	}
+
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return nil, pkg.Errors[0]
+		}
+		if len(pkg.GoFiles) == 0 {
+			continue
+		}
+		dir := filepath.Dir(pkg.GoFiles[0])
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		dirs = append(dirs, dir)
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no packages found for %q", pattern)
+	}
+	return dirs, nil
+}
+
+func buildFlags(tags string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	return []string{"-tags", tags}
+}
+
 func removeOldMocks(srcFolder string) error {
 	abs, err := filepath.Abs(srcFolder)
 	if err != nil {
@@ -141,23 +241,28 @@ func (m *Mocker) Mock(w io.Writer, name ...string) error {
 		return errors.New("must specify one interface")
 	}
 
-	if err := removeOldMocks(m.src); err != nil {
-		return errors.New("failed to clean up old mocks")
+	if !m.oldMocksRemoved {
+		if err := removeOldMocks(m.src); err != nil {
+			return errors.New("failed to clean up old mocks")
+		}
+		m.oldMocksRemoved = true
 	}
 
-	pkgInfo, err := m.pkgInfoFromPath(m.src)
-	if err != nil {
-		return err
-	}
+	// imports is scoped to this call: a batch caller may call Mock more than
+	// once against the same Mocker to split interfaces across several output
+	// files, and each file's import list must reflect only the interfaces
+	// written to it, not ones from an earlier call.
+	m.imports = make(map[string]bool)
 
 	doc := doc{
 		PackageName: m.pkgName,
 		Imports:     moqImports,
+		Style:       m.style,
 	}
 
 	mocksMethods := false
 
-	tpkg := pkgInfo.Pkg
+	tpkg := m.pkg.Types
 	for _, n := range name {
 		iface := tpkg.Scope().Lookup(n)
 		if iface == nil {
@@ -169,13 +274,16 @@ func (m *Mocker) Mock(w io.Writer, name ...string) error {
 		iiface := iface.Type().Underlying().(*types.Interface).Complete()
 		obj := obj{
 			InterfaceName: n,
+			MockName:      m.mockName(n),
+			TypeParams:    m.typeParams(iface.Type()),
 		}
 		for i := 0; i < iiface.NumMethods(); i++ {
 			mocksMethods = true
 			meth := iiface.Method(i)
 			sig := meth.Type().(*types.Signature)
 			method := &method{
-				Name: meth.Name(),
+				Interface: n,
+				Name:      meth.Name(),
 			}
 			obj.Methods = append(obj.Methods, method)
 			method.Params = m.extractArgs(sig, sig.Params(), "in%d")
@@ -187,37 +295,64 @@ func (m *Mocker) Mock(w io.Writer, name ...string) error {
 	if mocksMethods {
 		doc.Imports = append(doc.Imports, "sync")
 	}
+	if m.style == StyleExpect {
+		doc.Imports = append(doc.Imports,
+			"github.com/betalo-sweden/moq/pkg/moq/matcher",
+			"github.com/betalo-sweden/moq/pkg/moq/runtime",
+		)
+	}
 
 	for pkgToImport := range m.imports {
 		doc.Imports = append(doc.Imports, stripVendorPath(pkgToImport))
 	}
 
+	return m.render(w, doc)
+}
+
+// render executes the Mocker's template against doc, formats the result
+// with goimports, and writes it to w. Both the go/types-backed Mock and the
+// reflect-backed MockReflect funnel through here.
+func (m *Mocker) render(w io.Writer, doc doc) error {
 	var buf bytes.Buffer
-	err = m.tmpl.Execute(&buf, doc)
-	if err != nil {
+	if err := m.tmpl.Execute(&buf, doc); err != nil {
 		return err
 	}
 	formatted, err := imports.Process("", buf.Bytes(), nil)
 	if err != nil {
 		return fmt.Errorf("goimports: %s", err)
 	}
-	if _, err := w.Write(formatted); err != nil {
-		return err
+	_, err = w.Write(formatted)
+	return err
+}
+
+// typeParams extracts the type parameter list of a generic interface, e.g.
+// the [K comparable, V any] of `type Store[K comparable, V any] interface`.
+// It returns nil for a non-generic interface.
+func (m *Mocker) typeParams(ifaceType types.Type) []typeParam {
+	named, ok := ifaceType.(*types.Named)
+	if !ok {
+		return nil
+	}
+	tparams := named.TypeParams()
+	if tparams == nil || tparams.Len() == 0 {
+		return nil
+	}
+	params := make([]typeParam, tparams.Len())
+	for i := 0; i < tparams.Len(); i++ {
+		tp := tparams.At(i)
+		params[i] = typeParam{
+			Name:       tp.Obj().Name(),
+			Constraint: types.TypeString(tp.Constraint(), m.packageQualifier),
+		}
 	}
-	return nil
+	return params
 }
 
 func (m *Mocker) packageQualifier(pkg *types.Package) string {
-	path := pkg.Path()
-	importPath := filepath.Join(filepath.Dir(path), pkg.Name())
-
-	if importPath == m.pkgPath {
+	if pkg.Path() == m.pkgPath {
 		return ""
 	}
-	if path == "." {
-		path = m.pkgPath
-	}
-	m.imports[path] = true
+	m.imports[pkg.Path()] = true
 	return pkg.Name()
 }
 
@@ -243,44 +378,85 @@ func (m *Mocker) extractArgs(sig *types.Signature, list *types.Tuple, nameFormat
 	return params
 }
 
-func (*Mocker) pkgInfoFromPath(src string) (*loader.PackageInfo, error) {
-	pkgFull, err := pkgPath(src)
-	if err != nil {
-		return nil, err
-	}
-
-	conf := loader.Config{
-		ParserMode: parser.SpuriousErrors,
-		Cwd:        src,
-	}
-	conf.Import(pkgFull)
-	lprog, err := conf.Load()
-	if err != nil {
-		return nil, err
-	}
-
-	pkgInfo := lprog.Package(pkgFull)
-	if pkgInfo == nil {
-		return nil, errors.New("package was nil")
-	}
-
-	return pkgInfo, nil
-}
-
 type doc struct {
 	PackageName string
 	Objects     []obj
 	Imports     []string
+	Style       Style
 }
 
 type obj struct {
 	InterfaceName string
+	MockName      string
+	TypeParams    []typeParam
 	Methods       []*method
 }
+
+// typeParam describes one type parameter of a generic interface, e.g. the
+// `K comparable` in `type Store[K comparable, V any] interface { ... }`.
+type typeParam struct {
+	Name       string
+	Constraint string
+}
+
+// TypeParamList renders the interface's type parameter list for use in a
+// type declaration, e.g. "[K comparable, V any]", or "" for a non-generic
+// interface.
+func (o obj) TypeParamList() string {
+	if len(o.TypeParams) == 0 {
+		return ""
+	}
+	parts := make([]string, len(o.TypeParams))
+	for i, tp := range o.TypeParams {
+		parts[i] = tp.Name + " " + tp.Constraint
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// TypeParamNames renders just the parameter names, e.g. "[K, V]", for
+// instantiating the generated struct, e.g. "StoreMock[K, V]".
+func (o obj) TypeParamNames() string {
+	if len(o.TypeParams) == 0 {
+		return ""
+	}
+	names := make([]string, len(o.TypeParams))
+	for i, tp := range o.TypeParams {
+		names[i] = tp.Name
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
 type method struct {
-	Name    string
-	Params  []*param
-	Returns []*param
+	Interface string
+	Name      string
+	Params    []*param
+	Returns   []*param
+}
+
+// CallStructName returns the name of the *Call type -style=expect generates
+// for this method, e.g. "FooMockGetCall" for interface Foo's Get method.
+func (m *method) CallStructName() string {
+	return m.Interface + "Mock" + exportedIdent(m.Name) + "Call"
+}
+
+// MatcherArglist renders the parameter list for mock.EXPECT().Foo(...), with
+// each argument typed as a matcher.Matcher instead of its concrete type.
+func (m *method) MatcherArglist() string {
+	params := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		params[i] = fmt.Sprintf("%s matcher.Matcher", p.Name)
+	}
+	return strings.Join(params, ", ")
+}
+
+// MatcherCallList renders the arguments passed from mock.EXPECT().Foo(...)
+// through to the underlying *Call constructor.
+func (m *method) MatcherCallList() string {
+	params := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		params[i] = p.Name
+	}
+	return strings.Join(params, ", ")
 }
 
 func (m *method) Arglist() string {
@@ -335,26 +511,21 @@ func (p param) TypeString() string {
 }
 
 var templateFuncs = template.FuncMap{
-	"Exported": func(s string) string {
-		if s == "" {
-			return ""
-		}
-		for _, initialism := range golintInitialisms {
-			if strings.ToUpper(s) == initialism {
-				return initialism
-			}
-		}
-		return strings.ToUpper(s[0:1]) + s[1:]
-	},
+	"Exported": exportedIdent,
 }
 
-// pkgPath resolved the full package path from a source directory.
-func pkgPath(src string) (string, error) {
-	abs, err := filepath.Abs(src)
-	if err != nil {
-		return "", err
+// exportedIdent exports s the way golint expects, capitalizing known
+// initialisms (e.g. "id" -> "ID") instead of just the first letter.
+func exportedIdent(s string) string {
+	if s == "" {
+		return ""
 	}
-	return stripGopath(abs), nil
+	for _, initialism := range golintInitialisms {
+		if strings.ToUpper(s) == initialism {
+			return initialism
+		}
+	}
+	return strings.ToUpper(s[0:1]) + s[1:]
 }
 
 // stripVendorPath strips the vendor dir prefix from a package path.
@@ -368,22 +539,3 @@ func stripVendorPath(p string) string {
 	}
 	return strings.TrimLeft(path.Join(parts[1:]...), "/")
 }
-
-// stripGopath takes the directory to a package and remove the gopath to get the
-// canonical package name.
-//
-// taken from https://github.com/ernesto-jimenez/gogen
-// Copyright (c) 2015 Ernesto Jiménez
-func stripGopath(p string) string {
-	for _, gopath := range gopaths() {
-		p = strings.TrimPrefix(p, path.Join(gopath, "src")+"/")
-	}
-	return p
-}
-
-// gopaths returns a list of GOPATH paths.
-//
-// See https://github.com/golang/go/blob/master/src/cmd/go/internal/envcmd/env.go#L58
-func gopaths() []string {
-	return filepath.SplitList(build.Default.GOPATH)
-}
@@ -0,0 +1,353 @@
+package moq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/template"
+
+	"github.com/betalo-sweden/moq/pkg/moq/model"
+)
+
+// NewReflect makes a Mocker that mocks interfaces declared in a compiled,
+// third-party package found at importPath, e.g.
+// "github.com/aws/aws-sdk-go/service/s3". Unlike New, it never reads local
+// source: it builds a throwaway program that imports importPath and
+// reflects on the requested interfaces, so it works for packages you don't
+// own or vendor.
+// packageName is optional: when empty, it's filled in from the package's
+// real declared name once MockReflect has reflected on it, rather than
+// guessed from importPath's last segment (which is frequently wrong, e.g.
+// "gopkg.in/yaml.v3" declares package "yaml", not "yaml.v3").
+func NewReflect(importPath, packageName string, style Style) (*Mocker, error) {
+	if len(importPath) == 0 {
+		return nil, errors.New("must specify an import path")
+	}
+
+	tmplText := moqTemplate
+	if style == StyleExpect {
+		tmplText = moqExpectTemplate
+	}
+	tmpl, err := template.New("moq").Funcs(templateFuncs).Parse(tmplText)
+	if err != nil {
+		return nil, err
+	}
+	return &Mocker{
+		tmpl:              tmpl,
+		pkgName:           packageName,
+		pkgPath:           importPath,
+		reflectImportPath: importPath,
+		style:             style,
+		imports:           make(map[string]bool),
+	}, nil
+}
+
+// MockReflect generates a mock for the named interfaces of the package
+// passed to NewReflect. It is the -reflect counterpart to Mock.
+func (m *Mocker) MockReflect(w io.Writer, name ...string) error {
+	if len(name) == 0 {
+		return errors.New("must specify one interface")
+	}
+
+	pkg, err := reflectPackage(m.reflectImportPath, name)
+	if err != nil {
+		return err
+	}
+	if len(m.pkgName) == 0 {
+		m.pkgName = pkg.Name
+	}
+	byName := make(map[string]*model.Interface, len(pkg.Interfaces))
+	for _, iface := range pkg.Interfaces {
+		byName[iface.Name] = iface
+	}
+
+	doc := doc{
+		PackageName: m.pkgName,
+		Imports:     moqImports,
+		Style:       m.style,
+	}
+
+	mocksMethods := false
+	for _, n := range name {
+		iface, ok := byName[n]
+		if !ok {
+			return fmt.Errorf("cannot find interface %s", n)
+		}
+		obj := obj{InterfaceName: n, MockName: m.mockName(n)}
+		for _, meth := range iface.Methods {
+			mocksMethods = true
+			method := &method{
+				Interface: n,
+				Name:      meth.Name,
+			}
+			method.Params = m.modelParams(meth.In, "in%d", meth.Variadic)
+			method.Returns = m.modelParams(meth.Out, "out%d", false)
+			obj.Methods = append(obj.Methods, method)
+		}
+		doc.Objects = append(doc.Objects, obj)
+	}
+
+	if mocksMethods {
+		doc.Imports = append(doc.Imports, "sync")
+	}
+	if m.style == StyleExpect {
+		doc.Imports = append(doc.Imports,
+			"github.com/betalo-sweden/moq/pkg/moq/matcher",
+			"github.com/betalo-sweden/moq/pkg/moq/runtime",
+		)
+	}
+	for pkgToImport := range m.imports {
+		doc.Imports = append(doc.Imports, stripVendorPath(pkgToImport))
+	}
+
+	return m.render(w, doc)
+}
+
+// modelParams mirrors extractArgs for parameters resolved via reflection
+// rather than go/types.
+func (m *Mocker) modelParams(params []*model.Parameter, nameFormat string, variadic bool) []*param {
+	var out []*param
+	for i, p := range params {
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf(nameFormat, i+1)
+		}
+		typename := p.Type.String(m.reflectQualifier)
+		isVariadic := variadic && i == len(params)-1 && len(typename) >= 2 && typename[0:2] == "[]"
+		out = append(out, &param{
+			Name:     name,
+			Type:     typename,
+			Variadic: isVariadic,
+		})
+	}
+	return out
+}
+
+// reflectQualifier is the model.Qualifier for reflect-mode mocks: it
+// registers every non-local import path it's asked to spell out, the same
+// way packageQualifier does for the go/types-backed path. packageName is the
+// type's real declared package name, captured by the reflect driver program;
+// DefaultPackageName is only a fallback for the rare case it's missing.
+func (m *Mocker) reflectQualifier(importPath, packageName, typeName string) string {
+	if importPath == "" || importPath == m.pkgPath {
+		return typeName
+	}
+	m.imports[importPath] = true
+	if len(packageName) == 0 {
+		packageName = model.DefaultPackageName(importPath)
+	}
+	return packageName + "." + typeName
+}
+
+// reflectProgTemplate is compiled into a throwaway `go run` program that
+// imports only the target package and the standard library - never moq's
+// own module - and dumps a JSON tree structurally compatible with
+// model.Package describing the requested interfaces to stdout.
+//
+// It deliberately doesn't import github.com/betalo-sweden/moq/pkg/moq/model:
+// this program runs with `go run`'s module resolution rooted wherever the
+// moq binary happens to be invoked from (the caller's project, not moq's
+// own checkout), so an import of moq's own module here would resolve
+// against whatever version of moq (if any) that project happens to depend
+// on, not the running binary. Reflecting inline and emitting plain
+// map[string]interface{} JSON keeps this program's only non-stdlib
+// dependency on the package it's asked to mock.
+const reflectProgTemplate = `// Code generated by moq -reflect. DO NOT EDIT.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	pkg_ "{{.ImportPath}}"
+)
+
+// Kind values below mirror github.com/betalo-sweden/moq/pkg/moq/model.Kind;
+// reflectPackage decodes this program's output into that type.
+const (
+	kindPredeclared = 0
+	kindNamed       = 1
+	kindPointer     = 2
+	kindSlice       = 3
+	kindArray       = 4
+	kindMap         = 5
+	kindChan        = 6
+	kindFunc        = 7
+	kindEmptyIface  = 8
+)
+
+// declaredPackageName returns t's real declared package name, read off the
+// front of t.String() (e.g. "yaml" for yaml.Node), rather than guessed from
+// its import path's last segment, which is frequently wrong (e.g.
+// "gopkg.in/yaml.v3" declares package "yaml", not "yaml.v3").
+func declaredPackageName(t reflect.Type) string {
+	s := t.String()
+	if i := strings.Index(s, "."); i >= 0 {
+		return s[:i]
+	}
+	return ""
+}
+
+func typeToJSON(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return map[string]interface{}{"kind": kindPointer, "elem": typeToJSON(t.Elem())}
+	case reflect.Slice:
+		return map[string]interface{}{"kind": kindSlice, "elem": typeToJSON(t.Elem())}
+	case reflect.Array:
+		return map[string]interface{}{"kind": kindArray, "len": t.Len(), "elem": typeToJSON(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"kind": kindMap, "key": typeToJSON(t.Key()), "elem": typeToJSON(t.Elem())}
+	case reflect.Chan:
+		dir := ""
+		switch t.ChanDir() {
+		case reflect.RecvDir:
+			dir = "<-chan"
+		case reflect.SendDir:
+			dir = "chan<-"
+		}
+		return map[string]interface{}{"kind": kindChan, "chanDir": dir, "elem": typeToJSON(t.Elem())}
+	case reflect.Func:
+		in := make([]interface{}, t.NumIn())
+		for i := range in {
+			in[i] = typeToJSON(t.In(i))
+		}
+		out := make([]interface{}, t.NumOut())
+		for i := range out {
+			out[i] = typeToJSON(t.Out(i))
+		}
+		return map[string]interface{}{"kind": kindFunc, "in": in, "out": out, "variadic": t.IsVariadic()}
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return map[string]interface{}{"kind": kindEmptyIface}
+		}
+	}
+
+	if t.PkgPath() != "" {
+		return map[string]interface{}{
+			"kind":        kindNamed,
+			"importPath":  t.PkgPath(),
+			"packageName": declaredPackageName(t),
+			"typeName":    t.Name(),
+		}
+	}
+	return map[string]interface{}{"kind": kindPredeclared, "predeclaredName": t.String()}
+}
+
+func paramToJSON(t reflect.Type) map[string]interface{} {
+	return map[string]interface{}{"type": typeToJSON(t)}
+}
+
+func interfaceToJSON(name string, ptr interface{}) (map[string]interface{}, error) {
+	t := reflect.TypeOf(ptr)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		return nil, fmt.Errorf("%s: expected a nil pointer to an interface", name)
+	}
+	it := t.Elem()
+
+	var methods []interface{}
+	for i := 0; i < it.NumMethod(); i++ {
+		m := it.Method(i)
+
+		var in []interface{}
+		for j := 0; j < m.Type.NumIn(); j++ {
+			in = append(in, paramToJSON(m.Type.In(j)))
+		}
+		var out []interface{}
+		for j := 0; j < m.Type.NumOut(); j++ {
+			out = append(out, paramToJSON(m.Type.Out(j)))
+		}
+		methods = append(methods, map[string]interface{}{
+			"name":     m.Name,
+			"in":       in,
+			"out":      out,
+			"variadic": m.Type.IsVariadic(),
+		})
+	}
+	return map[string]interface{}{"name": name, "methods": methods}, nil
+}
+
+func main() {
+	named := []struct {
+		Name string
+		Ptr  interface{}
+	}{
+		{{range .Interfaces}}{Name: "{{.}}", Ptr: (*pkg_.{{.}})(nil)},
+		{{end}}
+	}
+
+	var ifaces []interface{}
+	pkgName := ""
+	for _, n := range named {
+		iface, err := interfaceToJSON(n.Name, n.Ptr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		ifaces = append(ifaces, iface)
+		if pkgName == "" {
+			pkgName = declaredPackageName(reflect.TypeOf(n.Ptr).Elem())
+		}
+	}
+
+	pkg := map[string]interface{}{"name": pkgName, "interfaces": ifaces}
+	if err := json.NewEncoder(os.Stdout).Encode(pkg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+`
+
+// reflectPackage writes and runs the reflect driver program for importPath
+// and decodes its JSON output into a model.Package.
+func reflectPackage(importPath string, names []string) (*model.Package, error) {
+	dir, err := ioutil.TempDir("", "moq-reflect")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	tmpl, err := template.New("moq-reflect-prog").Parse(reflectProgTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var src bytes.Buffer
+	err = tmpl.Execute(&src, struct {
+		ImportPath string
+		Interfaces []string
+	}{
+		ImportPath: importPath,
+		Interfaces: names,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	progFile := filepath.Join(dir, "prog.go")
+	if err := ioutil.WriteFile(progFile, src.Bytes(), 0600); err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", "run", progFile)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("reflecting %s: %s: %s", importPath, err, stderr.String())
+	}
+
+	var pkg model.Package
+	if err := json.Unmarshal(stdout.Bytes(), &pkg); err != nil {
+		return nil, fmt.Errorf("decoding reflect output for %s: %s", importPath, err)
+	}
+	return &pkg, nil
+}
@@ -0,0 +1,209 @@
+// Package runtime backs the code generated by moq's -style=expect mode: it
+// tracks expectations set up through `mock.EXPECT()`, matches incoming calls
+// against them in order, and reports unmet expectations on Finish.
+package runtime
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/betalo-sweden/moq/pkg/moq/matcher"
+)
+
+// TestHelper is the subset of *testing.T that generated mocks depend on.
+type TestHelper interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+}
+
+// Call represents a single expectation registered via `mock.EXPECT()`.
+type Call struct {
+	mu sync.Mutex
+
+	method  string
+	args    []matcher.Matcher
+	minCall int
+	maxCall int
+	maxSet  bool
+	numCall int
+
+	doAndReturn func(args []interface{}) []interface{}
+	preReqs     []*Call
+}
+
+// NewCall creates an expectation for method with the given argument
+// matchers. By default a Call must occur exactly once.
+func NewCall(method string, args ...matcher.Matcher) *Call {
+	return &Call{
+		method:  method,
+		args:    args,
+		minCall: 1,
+		maxCall: 1,
+	}
+}
+
+// Return sets the values the call returns when matched.
+func (c *Call) Return(rets ...interface{}) *Call {
+	c.doAndReturn = func([]interface{}) []interface{} {
+		return rets
+	}
+	return c
+}
+
+// DoAndReturn sets a function to compute the return values from the call's
+// arguments.
+func (c *Call) DoAndReturn(fn func(args []interface{}) []interface{}) *Call {
+	c.doAndReturn = fn
+	return c
+}
+
+// Times sets an exact expected call count.
+func (c *Call) Times(n int) *Call {
+	c.minCall, c.maxCall = n, n
+	c.maxSet = true
+	return c
+}
+
+// MinTimes sets the minimum expected call count. If no maximum has been set
+// explicitly yet, it also raises the maximum to unbounded, so a bare
+// MinTimes(n) behaves like "at least n" rather than leaving the default
+// maximum of 1 in place.
+func (c *Call) MinTimes(n int) *Call {
+	c.minCall = n
+	if !c.maxSet {
+		c.maxCall = 1<<31 - 1
+	}
+	return c
+}
+
+// MaxTimes sets the maximum expected call count.
+func (c *Call) MaxTimes(n int) *Call {
+	c.maxCall = n
+	c.maxSet = true
+	return c
+}
+
+// AnyTimes allows the call to match any number of times, including zero.
+func (c *Call) AnyTimes() *Call {
+	c.minCall, c.maxCall = 0, 1<<31-1
+	c.maxSet = true
+	return c
+}
+
+// After requires that preReq be exhausted before c can match.
+func (c *Call) After(preReq *Call) *Call {
+	c.preReqs = append(c.preReqs, preReq)
+	return c
+}
+
+func (c *Call) matches(args []interface{}) bool {
+	if len(args) != len(c.args) {
+		return false
+	}
+	for i, m := range c.args {
+		if !m.Matches(args[i]) {
+			return false
+		}
+	}
+	for _, preReq := range c.preReqs {
+		if !preReq.exhausted() {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Call) exhausted() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.numCall >= c.minCall
+}
+
+func (c *Call) call(args []interface{}) []interface{} {
+	c.mu.Lock()
+	c.numCall++
+	c.mu.Unlock()
+	if c.doAndReturn == nil {
+		return nil
+	}
+	return c.doAndReturn(args)
+}
+
+func (c *Call) exceeded() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.numCall >= c.maxCall
+}
+
+func (c *Call) String() string {
+	return fmt.Sprintf("%s(...)", c.method)
+}
+
+// CallSet tracks every expectation registered for a mock, per method.
+type CallSet struct {
+	mu    sync.Mutex
+	calls map[string][]*Call
+}
+
+// NewCallSet creates an empty CallSet.
+func NewCallSet() *CallSet {
+	return &CallSet{calls: make(map[string][]*Call)}
+}
+
+// Expect registers call as a new expectation.
+func (s *CallSet) Expect(call *Call) *Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[call.method] = append(s.calls[call.method], call)
+	return call
+}
+
+// FindMatch returns the first call for method that has not reached its
+// maximum call count, whose matchers accept args and whose After
+// prerequisites are exhausted, or an error describing why none matched.
+func (s *CallSet) FindMatch(method string, args []interface{}) (*Call, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, call := range s.calls[method] {
+		if call.exceeded() {
+			continue
+		}
+		if call.matches(args) {
+			return call, nil
+		}
+	}
+	return nil, fmt.Errorf("no matching expectation for %s with args %v", method, args)
+}
+
+// Call finds the matching expectation for method and invokes it, fatally
+// failing t if no expectation matches.
+func (s *CallSet) Call(t TestHelper, method string, args ...interface{}) []interface{} {
+	t.Helper()
+	call, err := s.FindMatch(method, args)
+	if err != nil {
+		t.Fatalf("%s", err)
+		return nil
+	}
+	return call.call(args)
+}
+
+// Finish reports, via t.Fatalf, every expectation that didn't meet its
+// minimum call count.
+func (s *CallSet) Finish(t TestHelper) {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, calls := range s.calls {
+		for _, call := range calls {
+			call.mu.Lock()
+			unmet := call.numCall < call.minCall
+			numCall, minCall := call.numCall, call.minCall
+			call.mu.Unlock()
+			if unmet {
+				t.Fatalf("expected call to %s at least %d time(s), got %d", call, minCall, numCall)
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package runtime_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/betalo-sweden/moq/pkg/moq/runtime"
+)
+
+// fakeT collects Fatalf messages instead of failing the test binary, so
+// tests can assert on exactly which expectations were reported unmet.
+type fakeT struct {
+	fatals []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Fatalf(format string, args ...interface{}) {
+	f.fatals = append(f.fatals, fmt.Sprintf(format, args...))
+}
+
+func TestCallSet_ExactCount(t *testing.T) {
+	s := runtime.NewCallSet()
+	s.Expect(runtime.NewCall("Get"))
+
+	ft := &fakeT{}
+	s.Call(ft, "Get")
+	s.Finish(ft)
+	if len(ft.fatals) != 0 {
+		t.Errorf("unexpected fatals: %v", ft.fatals)
+	}
+}
+
+func TestCallSet_ExactCountUnmet(t *testing.T) {
+	s := runtime.NewCallSet()
+	s.Expect(runtime.NewCall("Get"))
+
+	ft := &fakeT{}
+	s.Finish(ft)
+	if len(ft.fatals) != 1 {
+		t.Errorf("expected one unmet expectation, got %v", ft.fatals)
+	}
+}
+
+func TestCallSet_ExceededCountDoesNotMatch(t *testing.T) {
+	s := runtime.NewCallSet()
+	s.Expect(runtime.NewCall("Get"))
+
+	ft := &fakeT{}
+	s.Call(ft, "Get")
+	s.Call(ft, "Get")
+	if len(ft.fatals) != 1 {
+		t.Errorf("expected the second call to fail with no matching expectation, got %v", ft.fatals)
+	}
+}
+
+func TestCall_Times(t *testing.T) {
+	s := runtime.NewCallSet()
+	s.Expect(runtime.NewCall("Get").Times(2))
+
+	ft := &fakeT{}
+	s.Call(ft, "Get")
+	s.Call(ft, "Get")
+	s.Finish(ft)
+	if len(ft.fatals) != 0 {
+		t.Errorf("unexpected fatals: %v", ft.fatals)
+	}
+}
+
+// TestCall_TimesThenMinTimes is the regression test for the bug fixed in
+// MinTimes: a Call that's already had an explicit Times(1) set must not have
+// that upper bound silently widened to unbounded by a later MinTimes call.
+func TestCall_TimesThenMinTimes(t *testing.T) {
+	s := runtime.NewCallSet()
+	s.Expect(runtime.NewCall("Get").Times(1).MinTimes(1))
+
+	ft := &fakeT{}
+	s.Call(ft, "Get")
+	s.Call(ft, "Get")
+	if len(ft.fatals) != 1 {
+		t.Errorf("expected Times(1) to still cap the call at one match, got %v", ft.fatals)
+	}
+}
+
+func TestCall_MinTimesWithNoPriorTimes(t *testing.T) {
+	s := runtime.NewCallSet()
+	s.Expect(runtime.NewCall("Get").MinTimes(2))
+
+	ft := &fakeT{}
+	s.Call(ft, "Get")
+	s.Call(ft, "Get")
+	s.Call(ft, "Get")
+	s.Finish(ft)
+	if len(ft.fatals) != 0 {
+		t.Errorf("expected MinTimes(2) to allow more than 2 calls, got %v", ft.fatals)
+	}
+}
+
+func TestCall_MinTimesUnmet(t *testing.T) {
+	s := runtime.NewCallSet()
+	s.Expect(runtime.NewCall("Get").MinTimes(2))
+
+	ft := &fakeT{}
+	s.Call(ft, "Get")
+	s.Finish(ft)
+	if len(ft.fatals) != 1 {
+		t.Errorf("expected one unmet expectation, got %v", ft.fatals)
+	}
+}
+
+func TestCall_MaxTimes(t *testing.T) {
+	s := runtime.NewCallSet()
+	s.Expect(runtime.NewCall("Get").MaxTimes(2))
+
+	ft := &fakeT{}
+	s.Call(ft, "Get")
+	s.Call(ft, "Get")
+	s.Call(ft, "Get")
+	if len(ft.fatals) != 1 {
+		t.Errorf("expected the third call to exceed MaxTimes(2), got %v", ft.fatals)
+	}
+}
+
+func TestCall_AnyTimes(t *testing.T) {
+	s := runtime.NewCallSet()
+	s.Expect(runtime.NewCall("Get").AnyTimes())
+
+	ft := &fakeT{}
+	s.Finish(ft)
+	if len(ft.fatals) != 0 {
+		t.Errorf("expected AnyTimes to tolerate zero calls, got %v", ft.fatals)
+	}
+}
+
+func TestCall_After(t *testing.T) {
+	s := runtime.NewCallSet()
+	first := s.Expect(runtime.NewCall("Open"))
+	s.Expect(runtime.NewCall("Close").After(first))
+
+	ft := &fakeT{}
+	s.Call(ft, "Close")
+	if len(ft.fatals) != 1 {
+		t.Errorf("expected Close to fail to match before Open is exhausted, got %v", ft.fatals)
+	}
+
+	ft = &fakeT{}
+	s.Call(ft, "Open")
+	s.Call(ft, "Close")
+	if len(ft.fatals) != 0 {
+		t.Errorf("expected Close to match once Open is exhausted, got %v", ft.fatals)
+	}
+}
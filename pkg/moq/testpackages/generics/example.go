@@ -0,0 +1,9 @@
+package generics
+
+// Store is a generic key/value store, keyed by a comparable K and holding
+// any V, used to exercise moq's generic interface support.
+type Store[K comparable, V any] interface {
+	Get(key K) (V, error)
+	Set(key K, value V) error
+	Delete(key K)
+}